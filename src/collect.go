@@ -0,0 +1,140 @@
+/* clang-format off */
+/*
+ * @file collect.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package main
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/BinRacer/BestGithub/geoip"
+)
+
+// resolveTargetIPs 获取GitHub Meta的CIDR列表并展开为待测IP集合：IPv4网段
+// 完整展开为可用主机，IPv6网段因规模过大只取网络地址作为代表样本
+func resolveTargetIPs() ([]string, error) {
+	cidrs, err := getGitHubMetaIPs()
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		hosts, err := expandCIDRHosts(cidr)
+		if err == nil {
+			ips = append(ips, hosts...)
+			continue
+		}
+
+		if v6, v6Err := parseIPv6NetworkAddr(cidr); v6Err == nil {
+			ips = append(ips, v6)
+			continue
+		}
+
+		logger.Debugf("跳过CIDR: %s, 原因: %v", cidr, err)
+	}
+
+	return ips, nil
+}
+
+// maxConcurrentTargets限制同时在飞行中的探测goroutine（及其各自打开的
+// socket）数量，与-rate相互独立：-rate只控制探测发起的速率，完整CIDR
+// 展开后IP总数可能有数万个，若不限制并发度，-rate 0（不限速）会瞬间
+// 拉起与IP等量的goroutine，很容易打穿ulimit -n
+const maxConcurrentTargets = 256
+
+// scanTargets 并发对ips执行Ping、端口探测和可选的GeoIP富化，返回所有结果
+// （包括Ping失败的），调用方按需过滤PingSuccess
+func scanTargets(ips []string, cfg ScanConfig, pinger *Pinger, geoProvider geoip.Provider,
+	limiter *RateLimiter, timeout time.Duration) []IPInfo {
+	var wg sync.WaitGroup
+	results := make([]IPInfo, len(ips))
+	mu := sync.Mutex{}
+	sem := make(chan struct{}, maxConcurrentTargets)
+
+	for i, ip := range ips {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(index int, ipAddr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			limiter.Wait()
+			info := IPInfo{IP: ipAddr}
+
+			logger.Debugf("开始Ping测试: %s", ipAddr)
+			parsedIP := net.ParseIP(ipAddr)
+			stats, pingErr := pinger.Ping(parsedIP, 1, timeout)
+			pingSuccess := pingErr == nil && stats.Recv > 0
+			if pingErr != nil {
+				logger.Errorf("Ping %s 失败: %v", ipAddr, pingErr)
+				info.PingTime = timeout
+				info.PingSuccess = false
+			} else if pingSuccess {
+				logger.Infof("Ping %s 成功: %s", ipAddr, stats.Avg.String())
+				info.PingTime = stats.Avg
+				info.PingSuccess = true
+
+				logger.Debugf("开始端口测试: %s", ipAddr)
+				limiter.Wait()
+				info.Port22Open = probePort(cfg, ipAddr, 22, timeout)
+				limiter.Wait()
+				info.Port80Open = probePort(cfg, ipAddr, 80, timeout)
+				limiter.Wait()
+				info.Port443Open = probePort(cfg, ipAddr, 443, timeout)
+
+				logger.Infof("IP %s 端口状态: (%t), 80(%t), 443(%t)",
+					ipAddr, info.Port22Open, info.Port80Open, info.Port443Open)
+
+				if geoProvider != nil {
+					if rec, err := geoProvider.Lookup(ipAddr); err != nil {
+						logger.Debugf("GeoIP查询 %s 失败: %v", ipAddr, err)
+					} else {
+						info.Country = rec.Country
+						info.Region = rec.Region
+						info.City = rec.City
+						info.ISP = rec.ISP
+						info.ASN = rec.ASN
+						info.Lat = rec.Lat
+						info.Lon = rec.Lon
+					}
+				}
+			} else {
+				logger.Debugf("Ping %s 无响应", ipAddr)
+				info.PingTime = timeout
+				info.PingSuccess = false
+			}
+
+			mu.Lock()
+			results[index] = info
+			mu.Unlock()
+		}(i, ip)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,103 @@
+/* clang-format off */
+/*
+ * @file export.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// exportResults 按输出文件的扩展名(.json/.csv)选择格式，将测速结果写入磁盘，
+// 便于用户挑选地理位置最近的GitHub边缘节点
+func exportResults(path string, results []IPInfo) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return exportJSON(path, results)
+	case ".csv":
+		return exportCSV(path, results)
+	default:
+		return fmt.Errorf("不支持的导出格式: %s（仅支持 .json 或 .csv）", path)
+	}
+}
+
+// exportJSON 将结果序列化为带缩进的JSON文件
+func exportJSON(path string, results []IPInfo) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化JSON失败: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入JSON文件失败: %v", err)
+	}
+	return nil
+}
+
+// exportCSV 将结果写为CSV文件，表头包含汇总表的各列，另加Region、ISP和经纬度；
+// TLS/HTTPS验证相关字段不写入CSV
+func exportCSV(path string, results []IPInfo) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建CSV文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"IP", "PingTime", "Port22Open", "Port80Open", "Port443Open",
+		"Country", "Region", "City", "ISP", "ASN", "Lat", "Lon"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("写入CSV表头失败: %v", err)
+	}
+
+	for _, info := range results {
+		row := []string{
+			info.IP,
+			info.PingTime.String(),
+			strconv.FormatBool(info.Port22Open),
+			strconv.FormatBool(info.Port80Open),
+			strconv.FormatBool(info.Port443Open),
+			info.Country,
+			info.Region,
+			info.City,
+			info.ISP,
+			info.ASN,
+			strconv.FormatFloat(info.Lat, 'f', -1, 64),
+			strconv.FormatFloat(info.Lon, 'f', -1, 64),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("写入CSV记录失败: %v", err)
+		}
+	}
+	return nil
+}
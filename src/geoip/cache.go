@@ -0,0 +1,84 @@
+/* clang-format off */
+/*
+ * @file cache.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package geoip
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// diskCache 是一个以IP为键、将Record序列化为JSON文件的简单磁盘缓存，
+// 避免重复查询在线API触发限速或产生不必要的流量
+type diskCache struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// newDiskCache 创建缓存目录（若不存在）
+func newDiskCache(dir string) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建GeoIP缓存目录失败: %v", err)
+	}
+	return &diskCache{dir: dir}, nil
+}
+
+func (c *diskCache) path(ip string) string {
+	return filepath.Join(c.dir, ip+".json")
+}
+
+// get 从磁盘读取指定IP的缓存记录，不存在时返回ok=false
+func (c *diskCache) get(ip string) (Record, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := os.ReadFile(c.path(ip))
+	if err != nil {
+		return Record{}, false
+	}
+
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Record{}, false
+	}
+	return rec, true
+}
+
+// put 将查询结果写入磁盘缓存
+func (c *diskCache) put(ip string, rec Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(c.path(ip), data, 0644)
+}
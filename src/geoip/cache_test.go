@@ -0,0 +1,47 @@
+package geoip
+
+import "testing"
+
+func TestDiskCachePutGetRoundTrip(t *testing.T) {
+	cache, err := newDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("newDiskCache失败: %v", err)
+	}
+
+	want := Record{Country: "US", Region: "CA", City: "Mountain View", ISP: "Google", ASN: "AS15169", Lat: 37.4, Lon: -122.1}
+	cache.put("8.8.8.8", want)
+
+	got, ok := cache.get("8.8.8.8")
+	if !ok {
+		t.Fatal("get应命中刚写入的记录")
+	}
+	if got != want {
+		t.Fatalf("get = %+v, 期望 %+v", got, want)
+	}
+}
+
+func TestDiskCacheGetMiss(t *testing.T) {
+	cache, err := newDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("newDiskCache失败: %v", err)
+	}
+
+	if _, ok := cache.get("1.2.3.4"); ok {
+		t.Fatal("从未写入的IP应该未命中")
+	}
+}
+
+func TestDiskCachePutOverwrites(t *testing.T) {
+	cache, err := newDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("newDiskCache失败: %v", err)
+	}
+
+	cache.put("8.8.8.8", Record{Country: "US"})
+	cache.put("8.8.8.8", Record{Country: "CN"})
+
+	got, ok := cache.get("8.8.8.8")
+	if !ok || got.Country != "CN" {
+		t.Fatalf("get = %+v, ok=%v, 期望被第二次put覆盖为CN", got, ok)
+	}
+}
@@ -0,0 +1,93 @@
+/* clang-format off */
+/*
+ * @file geoip.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+
+// Package geoip 为已测速的IP提供地理位置和ASN归属信息的查询能力，
+// 支持离线MMDB库和在线API两种Provider，调用方可按需组合。
+package geoip
+
+import "fmt"
+
+// Record 描述一次地理位置/ASN查询的结果
+type Record struct {
+	Country string  // 国家
+	Region  string  // 省/州
+	City    string  // 城市
+	ISP     string  // 运营商
+	ASN     string  // 自治系统编号，如 "AS13335"
+	Lat     float64 // 纬度
+	Lon     float64 // 经度
+}
+
+// Provider 是地理位置查询的统一接口，离线MMDB库和在线API都实现该接口
+type Provider interface {
+	// Lookup 查询给定IP的地理位置信息
+	Lookup(ip string) (Record, error)
+	// Close 释放Provider持有的资源（文件句柄、HTTP客户端等）
+	Close() error
+}
+
+// ErrNotFound 表示Provider中没有该IP的记录
+var ErrNotFound = fmt.Errorf("geoip: 未找到该IP的地理位置记录")
+
+// ChainProvider 依次尝试多个Provider，返回第一个成功的查询结果，
+// 用于组合离线库（优先，免费且快）和在线API（兜底，覆盖率更高）
+type ChainProvider struct {
+	Providers []Provider
+}
+
+// NewChainProvider 按优先级顺序组合多个Provider
+func NewChainProvider(providers ...Provider) *ChainProvider {
+	return &ChainProvider{Providers: providers}
+}
+
+// Lookup 依次尝试每个Provider，直到查询成功或全部失败
+func (c *ChainProvider) Lookup(ip string) (Record, error) {
+	var lastErr error
+	for _, p := range c.Providers {
+		rec, err := p.Lookup(ip)
+		if err == nil {
+			return rec, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = ErrNotFound
+	}
+	return Record{}, lastErr
+}
+
+// Close 关闭链中所有Provider
+func (c *ChainProvider) Close() error {
+	var firstErr error
+	for _, p := range c.Providers {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
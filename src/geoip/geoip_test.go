@@ -0,0 +1,76 @@
+package geoip
+
+import (
+	"errors"
+	"testing"
+)
+
+// stubProvider是测试用的Provider实现，按配置返回固定记录或错误
+type stubProvider struct {
+	rec      Record
+	err      error
+	closeErr error
+	called   bool
+}
+
+func (s *stubProvider) Lookup(ip string) (Record, error) {
+	s.called = true
+	return s.rec, s.err
+}
+
+func (s *stubProvider) Close() error {
+	return s.closeErr
+}
+
+func TestChainProviderLookupUsesFirstSuccess(t *testing.T) {
+	first := &stubProvider{err: errors.New("第一个Provider未命中")}
+	second := &stubProvider{rec: Record{Country: "US"}}
+	third := &stubProvider{rec: Record{Country: "CN"}}
+
+	chain := NewChainProvider(first, second, third)
+	rec, err := chain.Lookup("1.1.1.1")
+	if err != nil {
+		t.Fatalf("Lookup返回错误: %v", err)
+	}
+	if rec.Country != "US" {
+		t.Fatalf("Lookup = %+v, 期望使用second的结果", rec)
+	}
+	if !first.called || !second.called {
+		t.Fatal("first和second都应该被调用")
+	}
+	if third.called {
+		t.Fatal("已经命中second，不应再调用third")
+	}
+}
+
+func TestChainProviderLookupAllFail(t *testing.T) {
+	wantErr := errors.New("最后一个Provider的错误")
+	chain := NewChainProvider(
+		&stubProvider{err: errors.New("第一个Provider的错误")},
+		&stubProvider{err: wantErr},
+	)
+
+	_, err := chain.Lookup("1.1.1.1")
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("Lookup错误 = %v, 期望最后一个Provider的错误", err)
+	}
+}
+
+func TestChainProviderLookupEmpty(t *testing.T) {
+	chain := NewChainProvider()
+	_, err := chain.Lookup("1.1.1.1")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Lookup错误 = %v, 期望ErrNotFound", err)
+	}
+}
+
+func TestChainProviderClose(t *testing.T) {
+	wantErr := errors.New("close失败")
+	first := &stubProvider{closeErr: wantErr}
+	second := &stubProvider{}
+
+	chain := NewChainProvider(first, second)
+	if err := chain.Close(); !errors.Is(err, wantErr) {
+		t.Fatalf("Close() = %v, 期望第一个Provider的错误", err)
+	}
+}
@@ -0,0 +1,117 @@
+/* clang-format off */
+/*
+ * @file mmdb.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// mmdbRecord 镜像GeoLite2-City/ASN等MaxMind格式数据库中常见的字段结构
+type mmdbRecord struct {
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// MMDBProvider 基于本地MaxMind格式（或兼容ip2region转换后）的.mmdb文件离线查询，
+// 不依赖网络，适合作为首选Provider
+type MMDBProvider struct {
+	reader *maxminddb.Reader
+}
+
+// NewMMDBProvider 加载指定路径的.mmdb文件
+func NewMMDBProvider(path string) (*MMDBProvider, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开MMDB文件失败: %v", err)
+	}
+	return &MMDBProvider{reader: reader}, nil
+}
+
+// Lookup 在离线数据库中查询IP的地理位置与ASN信息
+func (m *MMDBProvider) Lookup(ip string) (Record, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Record{}, fmt.Errorf("无效的IP地址: %s", ip)
+	}
+
+	var rec mmdbRecord
+	_, found, err := m.reader.LookupNetwork(parsed, &rec)
+	if err != nil {
+		return Record{}, fmt.Errorf("MMDB查询失败: %v", err)
+	}
+	if !found {
+		return Record{}, ErrNotFound
+	}
+
+	return mapMMDBRecord(rec), nil
+}
+
+// mapMMDBRecord 把mmdb.go原始的MaxMind字段结构映射为对外统一的Record，
+// 单独抽出来便于在不依赖真实.mmdb文件的情况下做单元测试
+func mapMMDBRecord(rec mmdbRecord) Record {
+	region := ""
+	if len(rec.Subdivisions) > 0 {
+		region = rec.Subdivisions[0].Names["en"]
+	}
+
+	asn := ""
+	if rec.AutonomousSystemNumber != 0 {
+		asn = fmt.Sprintf("AS%d", rec.AutonomousSystemNumber)
+	}
+
+	return Record{
+		Country: rec.Country.Names["en"],
+		Region:  region,
+		City:    rec.City.Names["en"],
+		ISP:     rec.AutonomousSystemOrganization,
+		ASN:     asn,
+		Lat:     rec.Location.Latitude,
+		Lon:     rec.Location.Longitude,
+	}
+}
+
+// Close 关闭底层mmdb文件句柄
+func (m *MMDBProvider) Close() error {
+	return m.reader.Close()
+}
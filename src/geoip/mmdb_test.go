@@ -0,0 +1,42 @@
+package geoip
+
+import "testing"
+
+func TestMapMMDBRecord(t *testing.T) {
+	var rec mmdbRecord
+	rec.Country.Names = map[string]string{"en": "United States"}
+	rec.City.Names = map[string]string{"en": "Mountain View"}
+	rec.Subdivisions = []struct {
+		Names map[string]string `maxminddb:"names"`
+	}{{Names: map[string]string{"en": "California"}}}
+	rec.Location.Latitude = 37.4
+	rec.Location.Longitude = -122.1
+	rec.AutonomousSystemNumber = 15169
+	rec.AutonomousSystemOrganization = "Google LLC"
+
+	got := mapMMDBRecord(rec)
+	want := Record{
+		Country: "United States",
+		Region:  "California",
+		City:    "Mountain View",
+		ISP:     "Google LLC",
+		ASN:     "AS15169",
+		Lat:     37.4,
+		Lon:     -122.1,
+	}
+	if got != want {
+		t.Fatalf("mapMMDBRecord = %+v, 期望 %+v", got, want)
+	}
+}
+
+func TestMapMMDBRecordMissingFields(t *testing.T) {
+	var rec mmdbRecord // 全部字段为零值，模拟查不到子字段的情况
+
+	got := mapMMDBRecord(rec)
+	if got.Region != "" {
+		t.Fatalf("Region = %q, 期望空字符串（无Subdivisions）", got.Region)
+	}
+	if got.ASN != "" {
+		t.Fatalf("ASN = %q, 期望空字符串（AutonomousSystemNumber为0）", got.ASN)
+	}
+}
@@ -0,0 +1,133 @@
+/* clang-format off */
+/*
+ * @file online.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package geoip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OnlineProvider 通过ip-api.com的免费JSON接口在线查询地理位置，
+// 作为离线MMDB库未命中时的兜底，内置限速和磁盘缓存以避免触发对方的速率限制
+type OnlineProvider struct {
+	client      *http.Client
+	cache       *diskCache
+	minInterval time.Duration
+	callMu      sync.Mutex // 保护lastCall，Lookup由每个IP各自的goroutine并发调用
+	lastCall    time.Time
+}
+
+// NewOnlineProvider 创建在线Provider，cacheDir为磁盘缓存目录，
+// requestsPerSecond<=0时默认采用ip-api.com免费额度的安全值（每秒1次）
+func NewOnlineProvider(cacheDir string, requestsPerSecond float64) (*OnlineProvider, error) {
+	cache, err := newDiskCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = 1
+	}
+
+	return &OnlineProvider{
+		client:      &http.Client{Timeout: 5 * time.Second},
+		cache:       cache,
+		minInterval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}, nil
+}
+
+// ipAPIResponse 对应 http://ip-api.com/json/<ip> 返回的JSON结构
+type ipAPIResponse struct {
+	Status  string  `json:"status"`
+	Message string  `json:"message"`
+	Country string  `json:"country"`
+	Region  string  `json:"regionName"`
+	City    string  `json:"city"`
+	ISP     string  `json:"isp"`
+	AS      string  `json:"as"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+}
+
+// Lookup 优先读取磁盘缓存，未命中时限速请求ip-api.com并写回缓存
+func (o *OnlineProvider) Lookup(ip string) (Record, error) {
+	if rec, ok := o.cache.get(ip); ok {
+		return rec, nil
+	}
+
+	o.throttle()
+
+	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,message,country,regionName,city,isp,as,lat,lon", ip)
+	resp, err := o.client.Get(url)
+	if err != nil {
+		return Record{}, fmt.Errorf("GeoIP在线查询请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body ipAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Record{}, fmt.Errorf("GeoIP在线查询响应解析失败: %v", err)
+	}
+	if body.Status != "success" {
+		return Record{}, fmt.Errorf("GeoIP在线查询失败: %s", body.Message)
+	}
+
+	rec := Record{
+		Country: body.Country,
+		Region:  body.Region,
+		City:    body.City,
+		ISP:     body.ISP,
+		ASN:     body.AS,
+		Lat:     body.Lat,
+		Lon:     body.Lon,
+	}
+	o.cache.put(ip, rec)
+	return rec, nil
+}
+
+// throttle 确保相邻两次在线请求之间至少间隔minInterval；callMu串行化所有
+// 调用方对lastCall的读写，否则并发goroutine会各自算出elapsed<minInterval
+// 并同时放行，限速形同虚设
+func (o *OnlineProvider) throttle() {
+	o.callMu.Lock()
+	defer o.callMu.Unlock()
+
+	elapsed := time.Since(o.lastCall)
+	if elapsed < o.minInterval {
+		time.Sleep(o.minInterval - elapsed)
+	}
+	o.lastCall = time.Now()
+}
+
+// Close 在线Provider没有需要释放的资源
+func (o *OnlineProvider) Close() error {
+	return nil
+}
@@ -29,6 +29,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
@@ -37,13 +38,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"golang.org/x/net/icmp"
-	"golang.org/x/net/ipv4"
 	"golang.org/x/term"
 
+	"github.com/BinRacer/BestGithub/geoip"
 	"github.com/sirupsen/logrus"
 )
 
@@ -60,14 +59,28 @@ const (
 	colorBold    = "\033[1m"
 )
 
-// IPInfo 存储IP地址、Ping时间和端口状态
+// IPInfo 存储IP地址、Ping时间、端口状态以及可选的地理位置/ASN信息
 type IPInfo struct {
-	IP          string
-	PingTime    time.Duration
-	PingSuccess bool
-	Port22Open  bool
-	Port80Open  bool
-	Port443Open bool
+	IP           string
+	PingTime     time.Duration
+	PingSuccess  bool
+	Port22Open   bool
+	Port80Open   bool
+	Port443Open  bool
+	Country      string
+	Region       string
+	City         string
+	ISP          string
+	ASN          string
+	Lat          float64
+	Lon          float64
+	TLSHandshake time.Duration
+	TLSProtocol  string
+	CertExpiry   time.Time
+	SANs         []string
+	HTTPStatus   int
+	TTFB         time.Duration
+	Score        float64
 }
 
 // 全局日志变量
@@ -192,20 +205,6 @@ func getGitHubMetaIPs() ([]string, error) {
 	return ips, nil
 }
 
-// 解析CIDR并获取单个IPv4地址
-func parseIPv4FromCIDR(cidr string) (string, error) {
-	ip, _, err := net.ParseCIDR(cidr)
-	if err != nil {
-		return "", fmt.Errorf("无效的CIDR格式: %s", cidr)
-	}
-
-	if ip.To4() == nil {
-		return "", fmt.Errorf("非IPv4地址: %s", cidr)
-	}
-
-	return ip.String(), nil
-}
-
 // 测试TCP端口可用性
 func testTCPPort(ip string, port int, timeout time.Duration) bool {
 	address := net.JoinHostPort(ip, strconv.Itoa(port))
@@ -219,63 +218,6 @@ func testTCPPort(ip string, port int, timeout time.Duration) bool {
 	return true
 }
 
-// Ping IPv4地址并返回响应时间
-func pingIPv4(ip string, timeout time.Duration) (time.Duration, bool, error) {
-	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
-	if err != nil {
-		return 0, false, fmt.Errorf("ICMP监听失败: %v", err)
-	}
-	defer conn.Close()
-
-	msg := icmp.Message{
-		Type: ipv4.ICMPTypeEcho,
-		Code: 0,
-		Body: &icmp.Echo{
-			ID:   os.Getpid() & 0xffff,
-			Seq:  1,
-			Data: []byte("HELLO"),
-		},
-	}
-
-	msgBytes, err := msg.Marshal(nil)
-	if err != nil {
-		return 0, false, fmt.Errorf("ICMP消息构造失败: %v", err)
-	}
-
-	dest := &net.IPAddr{IP: net.ParseIP(ip)}
-	if dest.IP == nil {
-		return 0, false, fmt.Errorf("无效的IP地址: %s", ip)
-	}
-
-	start := time.Now()
-	if _, err := conn.WriteTo(msgBytes, dest); err != nil {
-		return 0, false, fmt.Errorf("ICMP发送失败: %v", err)
-	}
-
-	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
-		return 0, false, fmt.Errorf("设置超时失败: %v", err)
-	}
-
-	recvBytes := make([]byte, 1500)
-	n, _, err := conn.ReadFrom(recvBytes)
-	if err != nil {
-		return 0, false, fmt.Errorf("ICMP接收失败: %v", err)
-	}
-
-	elapsed := time.Since(start)
-
-	recvMsg, err := icmp.ParseMessage(1, recvBytes[:n])
-	if err != nil {
-		return 0, false, fmt.Errorf("ICMP解析失败: %v", err)
-	}
-
-	if recvMsg.Type != ipv4.ICMPTypeEchoReply {
-		return 0, false, fmt.Errorf("非Echo回复类型: %v", recvMsg.Type)
-	}
-
-	return elapsed, true, nil
-}
-
 // 获取终端宽度
 func getTerminalWidth() int {
 	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
@@ -284,8 +226,32 @@ func getTerminalWidth() int {
 	return 80
 }
 
-// 主函数
+// 主函数：默认执行一次性CLI扫描；"serve"子命令启动常驻的HTTP面板模式
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe(os.Args[2:])
+		return
+	}
+	runOnce()
+}
+
+// runOnce 执行一次完整的CIDR展开->Ping->端口探测->GeoIP富化->汇总输出流程，
+// 这是重构前main()的原有行为，保持默认一次性运行的语义不变
+func runOnce() {
+	rate := flag.Int("rate", 0, "扫描速率限制，单位为次/秒（0表示不限速）")
+	shuffle := flag.Bool("shuffle", false, "扫描前随机打乱IP顺序，避免顺序集中冲击同一网段")
+	synScan := flag.Bool("synscan", false, "使用SYN探测代替完整TCP连接（需要root权限，否则自动回退）")
+	mmdbPath := flag.String("geoip-mmdb", "", "离线GeoIP数据库(.mmdb)路径，留空则不使用离线查询")
+	geoOnline := flag.Bool("geoip-online", false, "离线查询未命中时，使用在线API(ip-api.com)兜底")
+	geoCacheDir := flag.String("geoip-cache", "cache/geoip", "在线GeoIP查询结果的磁盘缓存目录")
+	output := flag.String("o", "", "导出结果到文件，按扩展名(.json/.csv)选择格式")
+	tracerouteN := flag.Int("traceroute", 0, "对排名前N的IP运行traceroute路径探测（默认按Ping排名，启用-https时按综合评分；0表示不执行）")
+	httpsCheck := flag.Bool("https", false, "对443开放的IP执行TLS握手+GET /验证，并按综合评分重新排序")
+	tlsSNI := flag.String("tls-sni", "github.com", "TLS握手使用的SNI，可选github.com/api.github.com/codeload.github.com/objects.githubusercontent.com")
+	flag.Parse()
+
+	cfg := ScanConfig{Rate: *rate, Shuffle: *shuffle, SynScan: *synScan}
+
 	initLogger()
 	logger.Info("程序启动")
 
@@ -294,76 +260,50 @@ func main() {
 		logger.Warnf("%s如果Ping测试失败，请尝试以管理员身份运行此程序%s", colorYellow, colorReset)
 	}
 
-	cidrs, err := getGitHubMetaIPs()
+	ips, err := resolveTargetIPs()
 	if err != nil {
 		logger.Errorf("%s错误: %v%s", colorRed, err, colorReset)
 		return
 	}
 
-	ips := make([]string, 0, len(cidrs))
-	for _, cidr := range cidrs {
-		ip, err := parseIPv4FromCIDR(cidr)
-		if err != nil {
-			logger.Debugf("跳过CIDR: %s, 原因: %v", cidr, err)
-			continue
-		}
-		ips = append(ips, ip)
-	}
-
 	if len(ips) == 0 {
-		logger.Errorf("%s错误: 没有有效的IPv4地址可测试%s", colorRed, colorReset)
+		logger.Errorf("%s错误: 没有有效的IP地址可测试%s", colorRed, colorReset)
 		return
 	}
 
-	logger.Infof("解析到 %d 个IPv4地址", len(ips))
+	if cfg.Shuffle {
+		shuffleIPs(ips)
+		logger.Info("已随机打乱待测IP顺序")
+	}
 
-	timeout := 5 * time.Second
-	var wg sync.WaitGroup
-	results := make([]IPInfo, len(ips))
-	mu := sync.Mutex{}
+	logger.Infof("解析到 %d 个待测IP地址", len(ips))
+	if cfg.Rate > 0 {
+		logger.Infof("已启用限速扫描: %d 次/秒", cfg.Rate)
+	}
+	if cfg.SynScan {
+		logger.Info("已启用SYN探测模式（无root权限时自动回退为普通连接）")
+	}
 
-	logger.Infof("开始测试IP地址，超时时间: %v", timeout)
-	logger.Info("只有Ping成功的IP才会进行端口测试")
+	limiter := NewRateLimiter(cfg.Rate)
+	defer limiter.Close()
 
-	for i, ip := range ips {
-		wg.Add(1)
-		go func(index int, ipAddr string) {
-			defer wg.Done()
-			info := IPInfo{IP: ipAddr}
-
-			logger.Debugf("开始Ping测试: %s", ipAddr)
-			pingTime, pingSuccess, pingErr := pingIPv4(ipAddr, timeout)
-			if pingErr != nil {
-				logger.Errorf("Ping %s 失败: %v", ipAddr, pingErr)
-				info.PingTime = timeout
-				info.PingSuccess = false
-			} else {
-				if pingSuccess {
-					logger.Infof("Ping %s 成功: %s", ipAddr, pingTime.String())
-					info.PingTime = pingTime
-					info.PingSuccess = true
-
-					logger.Debugf("开始端口测试: %s", ipAddr)
-					info.Port22Open = testTCPPort(ipAddr, 22, timeout)
-					info.Port80Open = testTCPPort(ipAddr, 80, timeout)
-					info.Port443Open = testTCPPort(ipAddr, 443, timeout)
-
-					logger.Infof("IP %s 端口状态: (%t), 80(%t), 443(%t)",
-						ipAddr, info.Port22Open, info.Port80Open, info.Port443Open)
-				} else {
-					logger.Debugf("Ping %s 响应", ipAddr)
-					info.PingTime = timeout
-					info.PingSuccess = false
-				}
-			}
+	pinger, err := NewPinger()
+	if err != nil {
+		logger.Errorf("%s错误: %v%s", colorRed, err, colorReset)
+		return
+	}
+	defer pinger.Close()
 
-			mu.Lock()
-			results[index] = info
-			mu.Unlock()
-		}(i, ip)
+	geoProvider := buildGeoProvider(*mmdbPath, *geoOnline, *geoCacheDir)
+	if geoProvider != nil {
+		defer geoProvider.Close()
 	}
 
-	wg.Wait()
+	timeout := 5 * time.Second
+	logger.Infof("开始测试IP地址，超时时间: %v", timeout)
+	logger.Info("只有Ping成功的IP才会进行端口测试")
+
+	results := scanTargets(ips, cfg, pinger, geoProvider, limiter, timeout)
 
 	successfulResults := make([]IPInfo, 0)
 	for _, info := range results {
@@ -379,17 +319,24 @@ func main() {
 
 	logger.Infof("Ping成功的IP数量: %d", len(successfulResults))
 
-	sort.Slice(successfulResults, func(i, j int) bool {
-		return successfulResults[i].PingTime < successfulResults[j].PingTime
-	})
+	if *httpsCheck {
+		probeHTTPSForAll(successfulResults, *tlsSNI, timeout)
+		sort.Slice(successfulResults, func(i, j int) bool {
+			return successfulResults[i].Score < successfulResults[j].Score
+		})
+	} else {
+		sort.Slice(successfulResults, func(i, j int) bool {
+			return successfulResults[i].PingTime < successfulResults[j].PingTime
+		})
+	}
 
 	logger.Infof("%s测试结果汇总:%s", colorBold+colorMagenta, colorReset)
 	terminalWidth := getTerminalWidth()
 	separator := strings.Repeat("=", terminalWidth)
 	logger.Infof("%s%s%s", colorMagenta, separator, colorReset)
-	logger.Infof("%s%-20s %-12s %-8s %-8s %-8s%s",
+	logger.Infof("%s%-20s %-12s %-8s %-8s %-8s %-15s %-10s %-15s%s",
 		colorBold+colorGreen,
-		"IP地址", "Ping时间", "端口22", "端口80", "端口443",
+		"IP地址", "Ping时间", "端口22", "端口80", "端口443", "国家/地区", "ASN", "城市",
 		colorReset)
 	logger.Infof("%s%s%s", colorMagenta, separator, colorReset)
 
@@ -417,12 +364,19 @@ func main() {
 			pingColor = colorRed
 		}
 
-		logger.Infof("%-20s %s%-12s%s %s%-8t%s %s%-8t%s %s%-8t%s",
+		logger.Infof("%-20s %s%-12s%s %s%-8t%s %s%-8t%s %s%-8t%s %-15s %-10s %-15s",
 			info.IP,
 			pingColor, info.PingTime.String(), colorReset,
 			port22Color, info.Port22Open, colorReset,
 			port80Color, info.Port80Open, colorReset,
-			port443Color, info.Port443Open, colorReset)
+			port443Color, info.Port443Open, colorReset,
+			info.Country, info.ASN, info.City)
+
+		if *httpsCheck {
+			logger.Infof("    TLS握手: %-10s 协议: %-10s TTFB: %-10s HTTP状态: %-4d 证书到期: %s",
+				info.TLSHandshake.String(), info.TLSProtocol, info.TTFB.String(),
+				info.HTTPStatus, info.CertExpiry.Format("2006-01-02"))
+		}
 	}
 
 	logger.Infof("%s%s%s", colorMagenta, separator, colorReset)
@@ -452,5 +406,85 @@ func main() {
 			colorBold+colorGreen, fastest.IP, fastest.PingTime.String(), colorReset)
 	}
 
+	if *output != "" {
+		if err := exportResults(*output, successfulResults); err != nil {
+			logger.Errorf("%s导出结果失败: %v%s", colorRed, err, colorReset)
+		} else {
+			logger.Infof("结果已导出到: %s", *output)
+		}
+	}
+
+	if *tracerouteN > 0 {
+		runTopNTraceroutes(successfulResults, *tracerouteN, geoProvider)
+	}
+
 	logger.Info("程序执行完成")
 }
+
+// runTopNTraceroutes 对results中排名前N的IP依次执行traceroute，并将路径作为
+// 汇总表的新增小节打印出来，帮助定位"Ping快但实际访问慢"的异步路由问题
+func runTopNTraceroutes(results []IPInfo, n int, geoProvider geoip.Provider) {
+	if n > len(results) {
+		n = len(results)
+	}
+
+	logger.Infof("%s开始对Top %d IP执行Traceroute路径探测%s", colorBold+colorMagenta, n, colorReset)
+
+	for _, info := range results[:n] {
+		trace, err := RunTraceroute(info.IP, 30, 2*time.Second, geoProvider)
+		if err != nil {
+			logger.Errorf("对 %s 执行traceroute失败: %v", info.IP, err)
+			continue
+		}
+
+		logger.Infof("%s路径: %s%s", colorCyan, info.IP, colorReset)
+		for _, hop := range trace.Hops {
+			if hop.IP == "" {
+				logger.Infof("  %2d  *", hop.TTL)
+				continue
+			}
+
+			line := fmt.Sprintf("  %2d  %-16s %-10s", hop.TTL, hop.IP, hop.RTT.String())
+			if hop.Hostname != "" {
+				line += fmt.Sprintf(" %s", hop.Hostname)
+			}
+			if hop.Geo != nil {
+				line += fmt.Sprintf(" [%s/%s]", hop.Geo.Country, hop.Geo.City)
+			}
+			for _, label := range hop.MPLS {
+				line += fmt.Sprintf(" MPLS(label=%d,exp=%d,ttl=%d,s=%t)",
+					label.Label, label.Exp, label.TTL, label.S)
+			}
+			logger.Info(line)
+		}
+	}
+}
+
+// buildGeoProvider 根据命令行参数组合离线MMDB和在线API两个Provider，
+// 两者都未配置时返回nil接口值，调用方需跳过GeoIP查询
+func buildGeoProvider(mmdbPath string, online bool, cacheDir string) geoip.Provider {
+	var providers []geoip.Provider
+
+	if mmdbPath != "" {
+		mmdb, err := geoip.NewMMDBProvider(mmdbPath)
+		if err != nil {
+			logger.Warnf("%s加载离线GeoIP数据库失败: %v%s", colorYellow, err, colorReset)
+		} else {
+			providers = append(providers, mmdb)
+		}
+	}
+
+	if online {
+		onlineProvider, err := geoip.NewOnlineProvider(cacheDir, 1)
+		if err != nil {
+			logger.Warnf("%s初始化在线GeoIP查询失败: %v%s", colorYellow, err, colorReset)
+		} else {
+			providers = append(providers, onlineProvider)
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil
+	}
+	return geoip.NewChainProvider(providers...)
+}
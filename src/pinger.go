@@ -0,0 +1,321 @@
+/* clang-format off */
+/*
+ * @file pinger.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// Stats 汇总一轮Ping的统计结果
+type Stats struct {
+	Sent   int
+	Recv   int
+	Loss   float64 // 丢包率，0.0~1.0
+	Min    time.Duration
+	Avg    time.Duration
+	Max    time.Duration
+	StdDev time.Duration
+}
+
+// pingKey 用(ID, Seq)标识一次未完成的Ping请求，用于从单一socket的
+// 读取协程中将ICMP回包分发给正确的等待者
+type pingKey struct {
+	id  uint32
+	seq uint32
+}
+
+// Pinger 在其生命周期内只打开一个ICMPv4和一个ICMPv6（或对应的
+// 非特权udp4/udp6）socket，通过单独的读取协程按(ID, Seq)解复用回包，
+// 取代此前每个goroutine各自创建socket的方式
+type Pinger struct {
+	conn4    net.PacketConn
+	conn6    net.PacketConn
+	raw4     bool
+	raw6     bool
+	id4      int      // 非特权udp4模式下使用的Echo ID，等于socket的本地端口
+	id6      int      // 非特权udp6模式下使用的Echo ID，等于socket的本地端口
+	pending  sync.Map // pingKey -> chan time.Time（回包到达时间）
+	seqCount uint32
+	closed   chan struct{}
+}
+
+// NewPinger 打开IPv4/IPv6 ICMP socket；如果没有原始套接字权限
+// （通常是非root），自动退化为udp4/udp6的非特权ICMP模式
+func NewPinger() (*Pinger, error) {
+	p := &Pinger{closed: make(chan struct{})}
+
+	conn4, raw4, err := listenICMPv4()
+	if err != nil {
+		return nil, fmt.Errorf("初始化ICMPv4 socket失败: %v", err)
+	}
+	p.conn4 = conn4
+	p.raw4 = raw4
+	p.id4 = echoID(conn4, raw4)
+	go p.readLoop(p.conn4, 4)
+
+	conn6, raw6, err := listenICMPv6()
+	if err != nil {
+		logger.Debugf("初始化ICMPv6 socket失败，已禁用IPv6 Ping: %v", err)
+	} else {
+		p.conn6 = conn6
+		p.raw6 = raw6
+		p.id6 = echoID(conn6, raw6)
+		go p.readLoop(p.conn6, 6)
+	}
+
+	return p, nil
+}
+
+// echoID确定本socket发出的Echo请求应使用的ID：原始ICMP socket下应用自选
+// 的ID（如pid）会被原样发出，但非特权的udp4/udp6 socket下，Linux内核会把
+// 出站Echo请求的ID静默改写为该socket绑定的本地端口，回包里的ID也是这个
+// 被改写过的值，因此非特权模式必须用本地端口作为ID，否则readLoop永远
+// 对不上pending中按pid记录的key，每次Ping都会判定为超时
+func echoID(conn net.PacketConn, raw bool) int {
+	if raw {
+		return os.Getpid() & 0xffff
+	}
+	if udpAddr, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+		return udpAddr.Port & 0xffff
+	}
+	return os.Getpid() & 0xffff
+}
+
+// listenICMPv4 优先打开原始ICMPv4 socket，失败时退化为非特权的udp4模式
+func listenICMPv4() (net.PacketConn, bool, error) {
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err == nil {
+		return conn, true, nil
+	}
+	logger.Debugf("原始ICMPv4 socket不可用（可能缺少root权限），尝试udp4: %v", err)
+
+	conn, err = icmp.ListenPacket("udp4", "0.0.0.0")
+	if err != nil {
+		return nil, false, err
+	}
+	return conn, false, nil
+}
+
+// listenICMPv6 优先打开原始ICMPv6 socket，失败时退化为非特权的udp6模式
+func listenICMPv6() (net.PacketConn, bool, error) {
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err == nil {
+		return conn, true, nil
+	}
+	logger.Debugf("原始ICMPv6 socket不可用（可能缺少root权限），尝试udp6: %v", err)
+
+	conn, err = icmp.ListenPacket("udp6", "::")
+	if err != nil {
+		return nil, false, err
+	}
+	return conn, false, nil
+}
+
+// readLoop 是该socket唯一的读取协程，收到回包后按(ID, Seq)查找等待中的
+// 请求并把回包到达时间投递给对应的channel，由Ping据此计算RTT
+func (p *Pinger) readLoop(conn net.PacketConn, family int) {
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-p.closed:
+				return
+			default:
+				continue
+			}
+		}
+
+		proto := ipv4.ICMPTypeEchoReply.Protocol()
+		if family == 6 {
+			proto = ipv6.ICMPTypeEchoReply.Protocol()
+		}
+
+		msg, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+
+		var echo *icmp.Echo
+		switch body := msg.Body.(type) {
+		case *icmp.Echo:
+			if family == 4 && msg.Type != ipv4.ICMPTypeEchoReply {
+				continue
+			}
+			if family == 6 && msg.Type != ipv6.ICMPTypeEchoReply {
+				continue
+			}
+			echo = body
+		default:
+			continue
+		}
+
+		key := pingKey{id: uint32(echo.ID), seq: uint32(echo.Seq)}
+		if ch, ok := p.pending.LoadAndDelete(key); ok {
+			ch.(chan time.Time) <- time.Now()
+		}
+	}
+}
+
+// Ping 向目标IP依次发送count个Echo请求，每个请求最多等待interval回包，
+// 收到回包或超时后立即发送下一个，返回RTT统计信息；
+// 自动根据IP版本选择ICMPv4或ICMPv6 socket
+func (p *Pinger) Ping(ip net.IP, count int, interval time.Duration) (Stats, error) {
+	isV6 := ip.To4() == nil
+	conn := p.conn4
+	family := 4
+	raw := p.raw4
+	id := p.id4
+	if isV6 {
+		conn = p.conn6
+		family = 6
+		raw = p.raw6
+		id = p.id6
+	}
+	if conn == nil {
+		return Stats{}, fmt.Errorf("该地址族的ICMP socket未初始化: %s", ip.String())
+	}
+
+	rtts := make([]time.Duration, 0, count)
+	sent := 0
+
+	for i := 0; i < count; i++ {
+		// Echo报文的Seq字段只有16位，回包里解析出的Seq也只有低16位
+		seq := int(atomic.AddUint32(&p.seqCount, 1) & 0xffff)
+		key := pingKey{id: uint32(id), seq: uint32(seq)}
+		replyCh := make(chan time.Time, 1)
+		p.pending.Store(key, replyCh)
+
+		msgBytes, err := buildEchoRequest(family, id, seq)
+		if err != nil {
+			p.pending.Delete(key)
+			return Stats{}, err
+		}
+
+		dest := destAddr(ip, raw)
+		start := time.Now()
+		if _, err := conn.WriteTo(msgBytes, dest); err != nil {
+			p.pending.Delete(key)
+			return Stats{}, fmt.Errorf("ICMP发送失败: %v", err)
+		}
+		sent++
+
+		select {
+		case <-replyCh:
+			rtts = append(rtts, time.Since(start))
+		case <-time.After(interval):
+			p.pending.Delete(key)
+		}
+	}
+
+	return computeStats(sent, rtts), nil
+}
+
+// buildEchoRequest 构造一个ICMP Echo请求报文
+func buildEchoRequest(family, id, seq int) ([]byte, error) {
+	echoType := icmp.Type(ipv4.ICMPTypeEcho)
+	if family == 6 {
+		echoType = ipv6.ICMPTypeEchoRequest
+	}
+
+	msg := icmp.Message{
+		Type: echoType,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   id,
+			Seq:  seq,
+			Data: []byte("HELLO"),
+		},
+	}
+	return msg.Marshal(nil)
+}
+
+// destAddr 根据socket是原始ICMP还是非特权udp模式，构造合适的目的地址类型：
+// 原始ICMP(ip4:icmp/ip6:ipv6-icmp)用net.IPAddr，udp4/udp6模式用net.UDPAddr
+func destAddr(ip net.IP, raw bool) net.Addr {
+	if raw {
+		return &net.IPAddr{IP: ip}
+	}
+	return &net.UDPAddr{IP: ip}
+}
+
+// computeStats 根据收集到的RTT样本计算最小/平均/最大值、标准差和丢包率
+func computeStats(sent int, rtts []time.Duration) Stats {
+	stats := Stats{Sent: sent, Recv: len(rtts)}
+	if sent > 0 {
+		stats.Loss = 1 - float64(len(rtts))/float64(sent)
+	}
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	stats.Min, stats.Max = rtts[0], rtts[0]
+	var sum time.Duration
+	for _, rtt := range rtts {
+		if rtt < stats.Min {
+			stats.Min = rtt
+		}
+		if rtt > stats.Max {
+			stats.Max = rtt
+		}
+		sum += rtt
+	}
+	stats.Avg = sum / time.Duration(len(rtts))
+
+	var variance float64
+	for _, rtt := range rtts {
+		diff := float64(rtt - stats.Avg)
+		variance += diff * diff
+	}
+	variance /= float64(len(rtts))
+	stats.StdDev = time.Duration(math.Sqrt(variance))
+
+	return stats
+}
+
+// Close 关闭底层socket并唤醒读取协程退出
+func (p *Pinger) Close() error {
+	close(p.closed)
+	if p.conn4 != nil {
+		p.conn4.Close()
+	}
+	if p.conn6 != nil {
+		p.conn6.Close()
+	}
+	return nil
+}
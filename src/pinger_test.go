@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStatsAllReplied(t *testing.T) {
+	rtts := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}
+	stats := computeStats(3, rtts)
+
+	if stats.Sent != 3 || stats.Recv != 3 {
+		t.Fatalf("Sent/Recv = %d/%d, want 3/3", stats.Sent, stats.Recv)
+	}
+	if stats.Loss != 0 {
+		t.Fatalf("Loss = %v, want 0", stats.Loss)
+	}
+	if stats.Min != 10*time.Millisecond || stats.Max != 30*time.Millisecond {
+		t.Fatalf("Min/Max = %v/%v, want 10ms/30ms", stats.Min, stats.Max)
+	}
+	if stats.Avg != 20*time.Millisecond {
+		t.Fatalf("Avg = %v, want 20ms", stats.Avg)
+	}
+}
+
+func TestComputeStatsPartialLoss(t *testing.T) {
+	rtts := []time.Duration{10 * time.Millisecond}
+	stats := computeStats(4, rtts)
+
+	if stats.Loss != 0.75 {
+		t.Fatalf("Loss = %v, want 0.75", stats.Loss)
+	}
+}
+
+func TestComputeStatsNoReply(t *testing.T) {
+	stats := computeStats(2, nil)
+
+	if stats.Recv != 0 || stats.Loss != 1 {
+		t.Fatalf("Recv/Loss = %d/%v, want 0/1", stats.Recv, stats.Loss)
+	}
+	if stats.Min != 0 || stats.Avg != 0 || stats.Max != 0 {
+		t.Fatalf("Min/Avg/Max应保持零值, got %v/%v/%v", stats.Min, stats.Avg, stats.Max)
+	}
+}
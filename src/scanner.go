@@ -0,0 +1,195 @@
+/* clang-format off */
+/*
+ * @file scanner.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+)
+
+// ScanConfig 描述一轮扫描所使用的速率、顺序和探测方式
+type ScanConfig struct {
+	Rate    int  // 每秒探测速率，<=0 表示不限速
+	Shuffle bool // 是否在扫描前随机打乱IP顺序
+	SynScan bool // 是否优先使用SYN探测而非完整TCP连接
+}
+
+// RateLimiter 基于令牌桶算法的简单限速器，单位为次/秒
+type RateLimiter struct {
+	ticker *time.Ticker
+	tokens chan struct{}
+	done   chan struct{}
+}
+
+// NewRateLimiter 创建一个速率限制器，rate<=0时表示不限速，返回nil
+func NewRateLimiter(rate int) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	rl := &RateLimiter{
+		ticker: time.NewTicker(time.Second / time.Duration(rate)),
+		tokens: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go rl.run()
+	return rl
+}
+
+// run 持续向令牌桶中补充令牌，直到Close被调用
+func (rl *RateLimiter) run() {
+	for {
+		select {
+		case <-rl.ticker.C:
+			select {
+			case rl.tokens <- struct{}{}:
+			default:
+			}
+		case <-rl.done:
+			rl.ticker.Stop()
+			return
+		}
+	}
+}
+
+// Wait 阻塞直到获取到一个令牌；limiter为nil时立即返回，表示不限速
+func (rl *RateLimiter) Wait() {
+	if rl == nil {
+		return
+	}
+	<-rl.tokens
+}
+
+// Close 释放限速器占用的资源
+func (rl *RateLimiter) Close() {
+	if rl == nil {
+		return
+	}
+	close(rl.done)
+}
+
+// expandCIDRHosts 将CIDR展开为其中所有可用的主机地址（排除网络地址和广播地址），
+// /31、/32 没有独立的网络/广播地址，全部保留
+func expandCIDRHosts(cidr string) ([]string, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的CIDR格式: %s", cidr)
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("非IPv4地址: %s", cidr)
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	hostBits := bits - ones
+	if hostBits <= 1 {
+		hosts := make([]string, 0, 1<<uint(hostBits))
+		for cur := cloneIP(ipNet.IP); ipNet.Contains(cur); incIP(cur) {
+			hosts = append(hosts, cur.String())
+		}
+		return hosts, nil
+	}
+
+	network := cloneIP(ipNet.IP)
+	broadcast := lastIP(ipNet)
+
+	hosts := make([]string, 0, (1<<uint(hostBits))-2)
+	for cur := cloneIP(network); ipNet.Contains(cur); incIP(cur) {
+		if cur.Equal(network) || cur.Equal(broadcast) {
+			continue
+		}
+		hosts = append(hosts, cur.String())
+	}
+	return hosts, nil
+}
+
+// parseIPv6NetworkAddr 解析IPv6 CIDR并返回其网络地址，用作该网段的代表样本
+func parseIPv6NetworkAddr(cidr string) (string, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", fmt.Errorf("无效的CIDR格式: %s", cidr)
+	}
+	if ip.To4() != nil || ip.To16() == nil {
+		return "", fmt.Errorf("非IPv6地址: %s", cidr)
+	}
+	return ipNet.IP.String(), nil
+}
+
+// cloneIP 返回IP的一份独立拷贝，避免原地自增污染调用方数据
+func cloneIP(ip net.IP) net.IP {
+	dup := make(net.IP, len(ip))
+	copy(dup, ip)
+	return dup
+}
+
+// incIP 对IP地址执行原地自增（大端字节序）
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			break
+		}
+	}
+}
+
+// lastIP 计算CIDR网段内的最后一个地址（广播地址）
+func lastIP(ipNet *net.IPNet) net.IP {
+	last := cloneIP(ipNet.IP.To4())
+	for i := range last {
+		last[i] |= ^ipNet.Mask[i]
+	}
+	return last
+}
+
+// shuffleIPs 随机打乱IP顺序，避免按序集中扫描同一个网段
+func shuffleIPs(ips []string) {
+	rand.Shuffle(len(ips), func(i, j int) {
+		ips[i], ips[j] = ips[j], ips[i]
+	})
+}
+
+// canSendRawSocket 判断当前进程是否具备发送原始套接字的权限（通常需要root）
+func canSendRawSocket() bool {
+	return os.Geteuid() == 0
+}
+
+// probePort 根据扫描配置选择探测方式：配置了SynScan且具备权限时优先使用
+// SYN探测，否则退化为完整的TCP连接探测
+func probePort(cfg ScanConfig, ip string, port int, timeout time.Duration) bool {
+	if cfg.SynScan && canSendRawSocket() {
+		ok, err := sendRawSYN(ip, port, timeout)
+		if err != nil {
+			logger.Debugf("SYN探测 %s:%d 失败，回退到普通连接: %v", ip, port, err)
+			return testTCPPort(ip, port, timeout)
+		}
+		return ok
+	}
+	return testTCPPort(ip, port, timeout)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestExpandCIDRHosts(t *testing.T) {
+	cases := []struct {
+		name string
+		cidr string
+		want []string
+	}{
+		{
+			name: "/30排除网络和广播地址",
+			cidr: "192.168.1.0/30",
+			want: []string{"192.168.1.1", "192.168.1.2"},
+		},
+		{
+			name: "/31没有独立的网络广播地址，全部保留",
+			cidr: "192.168.1.0/31",
+			want: []string{"192.168.1.0", "192.168.1.1"},
+		},
+		{
+			name: "/32只有自身一个地址",
+			cidr: "192.168.1.5/32",
+			want: []string{"192.168.1.5"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandCIDRHosts(tc.cidr)
+			if err != nil {
+				t.Fatalf("expandCIDRHosts(%q) 返回错误: %v", tc.cidr, err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expandCIDRHosts(%q) = %v, 期望 %v", tc.cidr, got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("expandCIDRHosts(%q)[%d] = %s, 期望 %s", tc.cidr, i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExpandCIDRHostsRejectsIPv6(t *testing.T) {
+	if _, err := expandCIDRHosts("2001:db8::/64"); err == nil {
+		t.Fatal("expandCIDRHosts 对IPv6网段应返回错误")
+	}
+}
+
+func TestIncIP(t *testing.T) {
+	ip := net.ParseIP("192.168.1.255").To4()
+	incIP(ip)
+	if ip.String() != "192.168.2.0" {
+		t.Fatalf("incIP 进位错误: got %s, want 192.168.2.0", ip.String())
+	}
+}
+
+func TestLastIP(t *testing.T) {
+	_, ipNet, err := net.ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("ParseCIDR失败: %v", err)
+	}
+	if got := lastIP(ipNet).String(); got != "10.0.0.255" {
+		t.Fatalf("lastIP(10.0.0.0/24) = %s, want 10.0.0.255", got)
+	}
+}
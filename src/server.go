@@ -0,0 +1,330 @@
+/* clang-format off */
+/*
+ * @file server.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BinRacer/BestGithub/geoip"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus指标：以IP（以及端口探测中的port）为标签，供长期监控面板使用
+var (
+	metricPingSeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_ip_ping_seconds",
+		Help: "GitHub边缘节点IP的Ping往返时延（秒）",
+	}, []string{"ip"})
+
+	metricPortOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_ip_port_open",
+		Help: "GitHub边缘节点IP的端口是否开放（1开放/0关闭）",
+	}, []string{"ip", "port"})
+
+	metricRank = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_ip_rank",
+		Help: "GitHub边缘节点IP按Ping时延排序后的名次（0为最快）",
+	}, []string{"ip"})
+)
+
+// dashboardState 持有serve模式下最近一次扫描的结果，受mu保护以支持
+// HTTP处理协程与定时扫描协程的并发访问
+type dashboardState struct {
+	mu      sync.RWMutex
+	results []IPInfo
+	lastRun time.Time
+}
+
+func (s *dashboardState) set(results []IPInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = results
+	s.lastRun = time.Now()
+}
+
+func (s *dashboardState) get() ([]IPInfo, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.results, s.lastRun
+}
+
+// runServe 是"serve"子命令的入口：常驻进程，按interval周期性重新执行
+// Meta拉取+Ping+端口扫描，并通过HTTP暴露仪表盘、JSON接口、Prometheus
+// 指标和健康检查，一次性CLI的默认行为不受影响
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", ":8080", "HTTP监听地址")
+	interval := fs.Duration("interval", 15*time.Minute, "重新扫描的周期，如 15m、1h")
+	rate := fs.Int("rate", 0, "扫描速率限制，单位为次/秒（0表示不限速）")
+	shuffle := fs.Bool("shuffle", false, "扫描前随机打乱IP顺序")
+	synScan := fs.Bool("synscan", false, "使用SYN探测代替完整TCP连接")
+	mmdbPath := fs.String("geoip-mmdb", "", "离线GeoIP数据库(.mmdb)路径")
+	geoOnline := fs.Bool("geoip-online", false, "离线查询未命中时使用在线API兜底")
+	geoCacheDir := fs.String("geoip-cache", "cache/geoip", "在线GeoIP查询结果的磁盘缓存目录")
+	if err := fs.Parse(args); err != nil {
+		return
+	}
+
+	initLogger()
+	logger.Info("程序启动（serve模式）")
+
+	cfg := ScanConfig{Rate: *rate, Shuffle: *shuffle, SynScan: *synScan}
+	geoProvider := buildGeoProvider(*mmdbPath, *geoOnline, *geoCacheDir)
+
+	state := &dashboardState{}
+
+	go scanLoop(state, cfg, geoProvider, *interval)
+	if geoProvider != nil {
+		defer geoProvider.Close()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", dashboardHandler(state))
+	mux.HandleFunc("/api/results.json", apiResultsHandler(state))
+	mux.HandleFunc("/healthz", healthzHandler(state))
+	mux.Handle("/metrics", promhttp.Handler())
+
+	logger.Infof("%s仪表盘已启动，监听地址: %s%s", colorGreen, *listen, colorReset)
+	if err := http.ListenAndServe(*listen, mux); err != nil {
+		logger.Errorf("%sHTTP服务器退出: %v%s", colorRed, err, colorReset)
+	}
+}
+
+// scanLoop 立即执行一次扫描，随后按interval周期性重复，将结果写入state
+// 并同步更新Prometheus指标
+func scanLoop(state *dashboardState, cfg ScanConfig, geoProvider geoip.Provider, interval time.Duration) {
+	runScanOnce(state, cfg, geoProvider)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		runScanOnce(state, cfg, geoProvider)
+	}
+}
+
+// runScanOnce 执行一轮完整扫描并更新仪表盘状态与Prometheus指标
+func runScanOnce(state *dashboardState, cfg ScanConfig, geoProvider geoip.Provider) {
+	ips, err := resolveTargetIPs()
+	if err != nil {
+		logger.Errorf("%s定时扫描获取IP失败: %v%s", colorRed, err, colorReset)
+		return
+	}
+	if cfg.Shuffle {
+		shuffleIPs(ips)
+	}
+
+	pinger, err := NewPinger()
+	if err != nil {
+		logger.Errorf("%s定时扫描初始化Pinger失败: %v%s", colorRed, err, colorReset)
+		return
+	}
+	defer pinger.Close()
+
+	limiter := NewRateLimiter(cfg.Rate)
+	defer limiter.Close()
+
+	results := scanTargets(ips, cfg, pinger, geoProvider, limiter, 5*time.Second)
+
+	successful := make([]IPInfo, 0)
+	for _, info := range results {
+		if info.PingSuccess {
+			successful = append(successful, info)
+		}
+	}
+	sort.Slice(successful, func(i, j int) bool {
+		return successful[i].PingTime < successful[j].PingTime
+	})
+
+	state.set(successful)
+	updateMetrics(successful)
+	logger.Infof("定时扫描完成，Ping成功 %d 个IP", len(successful))
+}
+
+// updateMetrics 将最新一轮的扫描结果同步到Prometheus指标
+func updateMetrics(results []IPInfo) {
+	for rank, info := range results {
+		metricPingSeconds.WithLabelValues(info.IP).Set(info.PingTime.Seconds())
+		metricRank.WithLabelValues(info.IP).Set(float64(rank))
+		metricPortOpen.WithLabelValues(info.IP, "22").Set(boolToFloat(info.Port22Open))
+		metricPortOpen.WithLabelValues(info.IP, "80").Set(boolToFloat(info.Port80Open))
+		metricPortOpen.WithLabelValues(info.IP, "443").Set(boolToFloat(info.Port443Open))
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// apiResultsHandler 以JSON形式返回最近一轮扫描结果，供程序化调用
+func apiResultsHandler(state *dashboardState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results, lastRun := state.get()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			LastRun time.Time `json:"last_run"`
+			Results []IPInfo  `json:"results"`
+		}{LastRun: lastRun, Results: results})
+	}
+}
+
+// healthzHandler 简单的健康检查端点：进程存活即返回200
+func healthzHandler(state *dashboardState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}
+}
+
+// dashboardTemplate 渲染按Ping时延排序、端口状态着色的排名表格
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>BestGithub 仪表盘</title>
+<style>
+body { font-family: sans-serif; background: #111; color: #eee; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #333; padding: 6px 10px; text-align: left; }
+th { cursor: pointer; background: #222; }
+.fast { color: #4caf50; }
+.mid { color: #ffc107; }
+.slow { color: #f44336; }
+.open { color: #4caf50; }
+.closed { color: #f44336; }
+</style>
+</head>
+<body>
+<h1>BestGithub 仪表盘</h1>
+<p>最近更新: {{.LastRun}}</p>
+<table id="results">
+<thead>
+<tr><th>IP</th><th>Ping</th><th>22</th><th>80</th><th>443</th><th>国家</th><th>ASN</th><th>城市</th></tr>
+</thead>
+<tbody>
+{{range .Results}}
+<tr>
+<td>{{.IP}}</td>
+<td class="{{.PingClass}}">{{.PingTime}}</td>
+<td class="{{.Port22Class}}">{{.Port22Open}}</td>
+<td class="{{.Port80Class}}">{{.Port80Open}}</td>
+<td class="{{.Port443Class}}">{{.Port443Open}}</td>
+<td>{{.Country}}</td>
+<td>{{.ASN}}</td>
+<td>{{.City}}</td>
+</tr>
+{{end}}
+</tbody>
+</table>
+<script>
+document.querySelectorAll('#results th').forEach(function (th, idx) {
+	th.addEventListener('click', function () {
+		var tbody = document.getElementById('results').tBodies[0];
+		var rows = Array.prototype.slice.call(tbody.rows);
+		var asc = th.dataset.asc !== 'true';
+
+		rows.sort(function (a, b) {
+			var av = a.cells[idx].textContent.trim();
+			var bv = b.cells[idx].textContent.trim();
+			var an = parseFloat(av), bn = parseFloat(bv);
+			var cmp = (!isNaN(an) && !isNaN(bn)) ? an - bn : av.localeCompare(bv);
+			return asc ? cmp : -cmp;
+		});
+
+		rows.forEach(function (row) { tbody.appendChild(row); });
+		th.parentNode.querySelectorAll('th').forEach(function (h) { delete h.dataset.asc; });
+		th.dataset.asc = asc;
+	});
+});
+</script>
+</body>
+</html>`))
+
+// dashboardRow 是IPInfo在模板中使用的视图模型，附加了着色用的CSS类名
+type dashboardRow struct {
+	IPInfo
+	PingClass    string
+	Port22Class  string
+	Port80Class  string
+	Port443Class string
+}
+
+func rowClass(open bool) string {
+	if open {
+		return "open"
+	}
+	return "closed"
+}
+
+func pingClass(d time.Duration) string {
+	switch {
+	case d > 300*time.Millisecond:
+		return "slow"
+	case d > 100*time.Millisecond:
+		return "mid"
+	default:
+		return "fast"
+	}
+}
+
+// dashboardHandler 渲染HTML仪表盘，展示当前排名及各列的颜色标注
+func dashboardHandler(state *dashboardState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results, lastRun := state.get()
+
+		rows := make([]dashboardRow, 0, len(results))
+		for _, info := range results {
+			rows = append(rows, dashboardRow{
+				IPInfo:       info,
+				PingClass:    pingClass(info.PingTime),
+				Port22Class:  rowClass(info.Port22Open),
+				Port80Class:  rowClass(info.Port80Open),
+				Port443Class: rowClass(info.Port443Open),
+			})
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(w, struct {
+			LastRun time.Time
+			Results []dashboardRow
+		}{LastRun: lastRun, Results: rows}); err != nil {
+			http.Error(w, fmt.Sprintf("渲染仪表盘失败: %v", err), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+/* clang-format off */
+/*
+ * @file synscan.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	tcpFlagSYN = 0x02
+	tcpFlagACK = 0x10
+)
+
+// sendRawSYN 构造一个原始TCP SYN包发送到目标ip:port，并等待SYN-ACK或RST响应。
+// 仅在具备原始套接字权限（通常是root）时可用，需要 golang.org/x/net/ipv4。
+func sendRawSYN(ip string, port int, timeout time.Duration) (bool, error) {
+	dstIP := net.ParseIP(ip).To4()
+	if dstIP == nil {
+		return false, fmt.Errorf("无效的IPv4地址: %s", ip)
+	}
+
+	rawConn, err := net.ListenPacket("ip4:tcp", "0.0.0.0")
+	if err != nil {
+		return false, fmt.Errorf("原始套接字监听失败: %v", err)
+	}
+	defer rawConn.Close()
+
+	conn, err := ipv4.NewRawConn(rawConn)
+	if err != nil {
+		return false, fmt.Errorf("创建原始连接失败: %v", err)
+	}
+
+	srcIP, err := localOutboundIP(dstIP)
+	if err != nil {
+		return false, fmt.Errorf("获取本地出口IP失败: %v", err)
+	}
+
+	srcPort := uint16(1024 + rand.Intn(60000))
+	seq := rand.Uint32()
+
+	tcpHeader := buildTCPSYN(srcPort, uint16(port), seq)
+	checksum := tcpChecksum(srcIP, dstIP, tcpHeader)
+	binary.BigEndian.PutUint16(tcpHeader[16:18], checksum)
+
+	iph := &ipv4.Header{
+		Version:  4,
+		Len:      ipv4.HeaderLen,
+		TotalLen: ipv4.HeaderLen + len(tcpHeader),
+		TTL:      64,
+		Protocol: 6, // TCP
+		Dst:      dstIP,
+	}
+
+	if err := conn.WriteTo(iph, tcpHeader, nil); err != nil {
+		return false, fmt.Errorf("发送SYN包失败: %v", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return false, fmt.Errorf("设置超时失败: %v", err)
+	}
+
+	buf := make([]byte, 1500)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		_, payload, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return false, fmt.Errorf("接收响应失败: %v", err)
+		}
+		if len(payload) < 20 {
+			continue
+		}
+
+		respSrcPort := binary.BigEndian.Uint16(payload[0:2])
+		respDstPort := binary.BigEndian.Uint16(payload[2:4])
+		respAck := binary.BigEndian.Uint32(payload[8:12])
+		flags := payload[13]
+
+		if respSrcPort != uint16(port) || respDstPort != srcPort {
+			continue
+		}
+		if respAck != seq+1 {
+			continue
+		}
+
+		if flags&tcpFlagSYN != 0 && flags&tcpFlagACK != 0 {
+			return true, nil
+		}
+		// RST：端口关闭
+		return false, nil
+	}
+
+	return false, nil
+}
+
+// localOutboundIP 通过向目标地址发起一次UDP"连接"（不发送数据包）来确定
+// 内核会选择的本地出口IP，用于构造TCP伪首部
+func localOutboundIP(dstIP net.IP) (net.IP, error) {
+	conn, err := net.Dial("udp4", net.JoinHostPort(dstIP.String(), "80"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.To4(), nil
+}
+
+// buildTCPSYN 构造一个不携带负载的TCP SYN报文段，校验和字段置0，
+// 由调用方用tcpChecksum计算伪首部校验和后填入
+func buildTCPSYN(srcPort, dstPort uint16, seq uint32) []byte {
+	header := make([]byte, 20)
+	binary.BigEndian.PutUint16(header[0:2], srcPort)
+	binary.BigEndian.PutUint16(header[2:4], dstPort)
+	binary.BigEndian.PutUint32(header[4:8], seq)
+	binary.BigEndian.PutUint32(header[8:12], 0) // ack
+	header[12] = 5 << 4                         // 数据偏移：5个32位字，无选项
+	header[13] = tcpFlagSYN
+	binary.BigEndian.PutUint16(header[14:16], 65535) // 窗口大小
+	binary.BigEndian.PutUint16(header[16:18], 0)      // 校验和，稍后填充
+	binary.BigEndian.PutUint16(header[18:20], 0)      // 紧急指针
+	return header
+}
+
+// tcpChecksum 计算TCP伪首部+报文段的校验和
+func tcpChecksum(srcIP, dstIP net.IP, tcpSegment []byte) uint16 {
+	pseudo := make([]byte, 12+len(tcpSegment))
+	copy(pseudo[0:4], srcIP.To4())
+	copy(pseudo[4:8], dstIP.To4())
+	pseudo[8] = 0
+	pseudo[9] = 6 // TCP协议号
+	binary.BigEndian.PutUint16(pseudo[10:12], uint16(len(tcpSegment)))
+	copy(pseudo[12:], tcpSegment)
+
+	var sum uint32
+	for i := 0; i+1 < len(pseudo); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(pseudo[i : i+2]))
+	}
+	if len(pseudo)%2 == 1 {
+		sum += uint32(pseudo[len(pseudo)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
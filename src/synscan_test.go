@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// TestTCPChecksum验证tcpChecksum的结果满足校验和的自验性质：把计算出的
+// 校验和写回报文段后，再对整个伪首部+报文段求和，结果应为0xffff（即取反后为0）
+func TestTCPChecksum(t *testing.T) {
+	srcIP := net.ParseIP("192.168.1.10")
+	dstIP := net.ParseIP("192.168.1.20")
+	segment := buildTCPSYN(12345, 80, 1)
+
+	checksum := tcpChecksum(srcIP, dstIP, segment)
+	binary.BigEndian.PutUint16(segment[16:18], checksum)
+
+	pseudo := make([]byte, 12+len(segment))
+	copy(pseudo[0:4], srcIP.To4())
+	copy(pseudo[4:8], dstIP.To4())
+	pseudo[8] = 0
+	pseudo[9] = 6
+	binary.BigEndian.PutUint16(pseudo[10:12], uint16(len(segment)))
+	copy(pseudo[12:], segment)
+
+	var sum uint32
+	for i := 0; i+1 < len(pseudo); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(pseudo[i : i+2]))
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+
+	if sum != 0xffff {
+		t.Fatalf("校验和自验失败: got %#x, want 0xffff", sum)
+	}
+}
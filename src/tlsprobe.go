@@ -0,0 +1,169 @@
+/* clang-format off */
+/*
+ * @file tlsprobe.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxConcurrentTLSProbes限制同时进行的TLS+HTTP验证数量，避免在端口443
+// 开放的IP数量很大时（完整CIDR展开后可能有成百上千个）串行逐个握手耗时过长
+const maxConcurrentTLSProbes = 64
+
+// 复合评分中各项的权重：Ping延迟、TLS握手耗时、首字节时间(TTFB)
+const (
+	scoreWeightPing      = 0.4
+	scoreWeightHandshake = 0.3
+	scoreWeightTTFB      = 0.3
+)
+
+// TLSProbeResult 记录一次HTTPS可达性探测的结果，不仅证明端口开放，
+// 还验证了证书链、协商协议以及真实的页面响应情况
+type TLSProbeResult struct {
+	HandshakeTime time.Duration
+	Protocol      string // ALPN协商结果，如 h2 / http/1.1
+	CertExpiry    time.Time
+	SANs          []string
+	StatusCode    int
+	TTFB          time.Duration
+}
+
+// probeHTTPS 对目标IP的443端口执行TLS握手（SNI=sni），校验证书链后
+// 在同一连接上发起一次GET /请求，记录握手耗时、协商协议、证书到期时间、
+// SAN列表以及状态码和首字节时间
+func probeHTTPS(ip, sni string, timeout time.Duration) (TLSProbeResult, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	rawConn, err := dialer.Dial("tcp", net.JoinHostPort(ip, "443"))
+	if err != nil {
+		return TLSProbeResult{}, fmt.Errorf("TCP连接失败: %v", err)
+	}
+	defer rawConn.Close()
+
+	tlsConfig := &tls.Config{
+		ServerName: sni,
+		NextProtos: []string{"h2", "http/1.1"},
+		MinVersion: tls.VersionTLS12,
+	}
+
+	tlsConn := tls.Client(rawConn, tlsConfig)
+	_ = tlsConn.SetDeadline(time.Now().Add(timeout))
+
+	handshakeStart := time.Now()
+	if err := tlsConn.Handshake(); err != nil {
+		return TLSProbeResult{}, fmt.Errorf("TLS握手失败: %v", err)
+	}
+	handshakeTime := time.Since(handshakeStart)
+
+	state := tlsConn.ConnectionState()
+	result := TLSProbeResult{HandshakeTime: handshakeTime, Protocol: state.NegotiatedProtocol}
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		result.CertExpiry = cert.NotAfter
+		result.SANs = cert.DNSNames
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://"+sni+"/", nil)
+	if err != nil {
+		return result, fmt.Errorf("构造HTTP请求失败: %v", err)
+	}
+	req.Host = sni
+	req.Close = true
+
+	ttfbStart := time.Now()
+	if err := req.Write(tlsConn); err != nil {
+		return result, fmt.Errorf("发送HTTP请求失败: %v", err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(tlsConn), req)
+	if err != nil {
+		return result, fmt.Errorf("读取HTTP响应失败: %v", err)
+	}
+	result.TTFB = time.Since(ttfbStart)
+	result.StatusCode = resp.StatusCode
+	resp.Body.Close()
+
+	return result, nil
+}
+
+// compositeScore 计算加权评分（秒为单位，越小越快），用于在启用HTTPS
+// 探测时按端到端体验排序，而不是只看Ping延迟
+func compositeScore(ping, handshake, ttfb time.Duration) float64 {
+	return scoreWeightPing*ping.Seconds() +
+		scoreWeightHandshake*handshake.Seconds() +
+		scoreWeightTTFB*ttfb.Seconds()
+}
+
+// unreachablePenalty 是443端口不可用或TLS/HTTP验证失败时施加的评分惩罚，
+// 确保这些IP在综合排序中被排到真正验证通过的IP之后
+const unreachablePenalty = 999
+
+// probeHTTPSForAll 对每个443端口开放的IP并发执行TLS+HTTP验证并计算综合评分
+// （并发数受maxConcurrentTLSProbes限制），端口未开放或验证失败的IP会被
+// 施加固定惩罚以排到结果末尾
+func probeHTTPSForAll(results []IPInfo, sni string, timeout time.Duration) {
+	sem := make(chan struct{}, maxConcurrentTLSProbes)
+	var wg sync.WaitGroup
+
+	for i := range results {
+		info := &results[i]
+		if !info.Port443Open {
+			info.Score = info.PingTime.Seconds() + unreachablePenalty
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(info *IPInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			probe, err := probeHTTPS(info.IP, sni, timeout)
+			if err != nil {
+				logger.Debugf("HTTPS验证 %s 失败: %v", info.IP, err)
+				info.Score = info.PingTime.Seconds() + unreachablePenalty
+				return
+			}
+
+			info.TLSHandshake = probe.HandshakeTime
+			info.TLSProtocol = probe.Protocol
+			info.CertExpiry = probe.CertExpiry
+			info.SANs = probe.SANs
+			info.HTTPStatus = probe.StatusCode
+			info.TTFB = probe.TTFB
+			info.Score = compositeScore(info.PingTime, probe.HandshakeTime, probe.TTFB)
+		}(info)
+	}
+
+	wg.Wait()
+}
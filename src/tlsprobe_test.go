@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompositeScore(t *testing.T) {
+	ping := 100 * time.Millisecond
+	handshake := 200 * time.Millisecond
+	ttfb := 300 * time.Millisecond
+
+	got := compositeScore(ping, handshake, ttfb)
+	want := scoreWeightPing*ping.Seconds() +
+		scoreWeightHandshake*handshake.Seconds() +
+		scoreWeightTTFB*ttfb.Seconds()
+
+	if got != want {
+		t.Fatalf("compositeScore(%v, %v, %v) = %v, want %v", ping, handshake, ttfb, got, want)
+	}
+}
+
+func TestCompositeScoreZero(t *testing.T) {
+	if got := compositeScore(0, 0, 0); got != 0 {
+		t.Fatalf("compositeScore(0,0,0) = %v, want 0", got)
+	}
+}
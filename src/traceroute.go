@@ -0,0 +1,262 @@
+/* clang-format off */
+/*
+ * @file traceroute.go
+ * @date 2025-08-27
+ * @license MIT License
+ *
+ * Copyright (c) 2025 BinRacer <native.lab@outlook.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+/* clang-format on */
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+
+	"github.com/BinRacer/BestGithub/geoip"
+)
+
+const (
+	defaultMaxHops     = 30
+	probesPerHop       = 3
+	tracerouteBasePort = 33434
+)
+
+// MPLSLabel对应RFC 4950中ICMP附加信息携带的MPLS标签栈条目
+type MPLSLabel struct {
+	Label uint32
+	Exp   uint8 // 流量类别（Traffic Class/EXP位）
+	TTL   uint8
+	S     bool // 是否为栈底标签
+}
+
+// Hop 描述traceroute路径上的一跳
+type Hop struct {
+	TTL      int
+	IP       string
+	RTT      time.Duration
+	Hostname string
+	Geo      *geoip.Record
+	MPLS     []MPLSLabel
+	Reached  bool // true表示这一跳就是最终目的地
+}
+
+// TraceResult 汇总一次traceroute的完整路径
+type TraceResult struct {
+	Target  string
+	Hops    []Hop
+	Reached bool
+}
+
+// RunTraceroute 对目标IPv4地址执行UDP traceroute：每个TTL发送probesPerHop个
+// 探测包，解析返回的ICMP Time Exceeded/Destination Unreachable消息，
+// 并提取其中按RFC 4950携带的MPLS标签栈扩展
+func RunTraceroute(target string, maxHops int, timeout time.Duration, geoProvider geoip.Provider) (TraceResult, error) {
+	if maxHops <= 0 {
+		maxHops = defaultMaxHops
+	}
+
+	dstIP := net.ParseIP(target)
+	if dstIP == nil || dstIP.To4() == nil {
+		return TraceResult{}, fmt.Errorf("traceroute目前仅支持IPv4目标: %s", target)
+	}
+
+	icmpConn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return TraceResult{}, fmt.Errorf("监听ICMP失败（traceroute需要原始套接字权限）: %v", err)
+	}
+	defer icmpConn.Close()
+
+	result := TraceResult{Target: target}
+
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		hop, reached, hopErr := probeHop(icmpConn, dstIP, ttl, timeout)
+		if hopErr != nil {
+			logger.Debugf("traceroute第%d跳探测失败: %v", ttl, hopErr)
+			result.Hops = append(result.Hops, Hop{TTL: ttl})
+			continue
+		}
+
+		if geoProvider != nil && hop.IP != "" {
+			if rec, gErr := geoProvider.Lookup(hop.IP); gErr == nil {
+				hop.Geo = &rec
+			}
+		}
+		if hop.IP != "" {
+			if names, rErr := net.LookupAddr(hop.IP); rErr == nil && len(names) > 0 {
+				hop.Hostname = names[0]
+			}
+		}
+
+		result.Hops = append(result.Hops, hop)
+		if reached {
+			result.Reached = true
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// probeHop 在给定TTL下最多发送probesPerHop个UDP探测包，返回其中RTT最短的一跳
+func probeHop(icmpConn *icmp.PacketConn, dst net.IP, ttl int, timeout time.Duration) (Hop, bool, error) {
+	hop := Hop{TTL: ttl}
+	gotReply := false
+
+	dstPort := tracerouteBasePort + ttl
+
+	for i := 0; i < probesPerHop; i++ {
+		udpConn, err := net.ListenPacket("udp4", "0.0.0.0:0")
+		if err != nil {
+			return Hop{}, false, fmt.Errorf("创建UDP探测套接字失败: %v", err)
+		}
+		srcPort := udpConn.LocalAddr().(*net.UDPAddr).Port
+		pconn := ipv4.NewPacketConn(udpConn)
+		_ = pconn.SetTTL(ttl)
+
+		start := time.Now()
+		dstAddr := &net.UDPAddr{IP: dst, Port: dstPort}
+		_, writeErr := udpConn.WriteTo([]byte("BestGithub-traceroute"), dstAddr)
+		udpConn.Close()
+		if writeErr != nil {
+			continue
+		}
+
+		// 一个TTL最多发probesPerHop个探测包，共享同一个icmpConn读取，
+		// 所以读到的下一个包不一定是当前这一个探测的回应，可能是上一个
+		// 探测迟到的回复。必须核对ICMP错误消息内嵌的原始IP/UDP头，确认
+		// 其目的端口/源端口与本次探测一致，否则会把串话的包错记成这一跳
+		deadline := time.Now().Add(timeout)
+		for {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				break
+			}
+			if err := icmpConn.SetReadDeadline(deadline); err != nil {
+				break
+			}
+
+			buf := make([]byte, 1500)
+			n, peer, readErr := icmpConn.ReadFrom(buf)
+			if readErr != nil {
+				break
+			}
+			rtt := time.Since(start)
+
+			msg, parseErr := icmp.ParseMessage(1, buf[:n])
+			if parseErr != nil {
+				continue
+			}
+
+			switch body := msg.Body.(type) {
+			case *icmp.TimeExceeded:
+				if !embeddedProbeMatches(body.Data, dst, srcPort, dstPort) {
+					continue
+				}
+				if !gotReply || rtt < hop.RTT {
+					hop.IP = peerIP(peer)
+					hop.RTT = rtt
+					hop.MPLS = extractMPLS(body.Extensions)
+					gotReply = true
+				}
+			case *icmp.DstUnreach:
+				if !embeddedProbeMatches(body.Data, dst, srcPort, dstPort) {
+					continue
+				}
+				hop.IP = peerIP(peer)
+				hop.RTT = rtt
+				hop.MPLS = extractMPLS(body.Extensions)
+				hop.Reached = true
+				return hop, true, nil
+			default:
+				// 原始ip4:icmp socket收的是整机的ICMP流量（重定向、无关的
+				// Echo Reply等），不是这次探测的回应，继续在剩余时间内等待
+				continue
+			}
+			break
+		}
+	}
+
+	if !gotReply {
+		return hop, false, fmt.Errorf("第%d跳无响应", ttl)
+	}
+	return hop, false, nil
+}
+
+// extractMPLS 从ICMP扩展对象中提取MPLS标签栈（如果携带了该扩展）
+func extractMPLS(extensions []icmp.Extension) []MPLSLabel {
+	var labels []MPLSLabel
+	for _, ext := range extensions {
+		stack, ok := ext.(*icmp.MPLSLabelStack)
+		if !ok {
+			continue
+		}
+		for _, l := range stack.Labels {
+			labels = append(labels, MPLSLabel{
+				Label: uint32(l.Label),
+				Exp:   uint8(l.TC),
+				TTL:   uint8(l.TTL),
+				S:     l.S,
+			})
+		}
+	}
+	return labels
+}
+
+// embeddedProbeMatches 核对ICMP Time Exceeded/Destination Unreachable消息
+// 内嵌的原始IP+UDP头，确认它确实对应本次发出的探测包（而不是其它TTL/其它
+// 探测次数迟到的回复）：目的IP须为dst，UDP目的端口须为dstPort，
+// UDP源端口须为发出探测包的那个本地端口
+func embeddedProbeMatches(data []byte, dst net.IP, srcPort, dstPort int) bool {
+	if len(data) < 20 {
+		return false
+	}
+	ihl := int(data[0]&0x0f) * 4
+	if ihl < 20 || len(data) < ihl+4 {
+		return false
+	}
+
+	origDst := net.IP(data[16:20])
+	if dst4 := dst.To4(); dst4 == nil || !origDst.Equal(dst4) {
+		return false
+	}
+
+	origSrcPort := binary.BigEndian.Uint16(data[ihl : ihl+2])
+	origDstPort := binary.BigEndian.Uint16(data[ihl+2 : ihl+4])
+	return int(origSrcPort) == srcPort && int(origDstPort) == dstPort
+}
+
+// peerIP 从ICMP响应的来源地址中提取IP字符串
+func peerIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.String()
+	case *net.UDPAddr:
+		return a.IP.String()
+	default:
+		return addr.String()
+	}
+}
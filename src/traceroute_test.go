@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/net/icmp"
+)
+
+func TestExtractMPLSWithLabelStack(t *testing.T) {
+	extensions := []icmp.Extension{
+		&icmp.MPLSLabelStack{
+			Labels: []icmp.MPLSLabel{
+				{Label: 1000, TC: 2, S: true, TTL: 64},
+				{Label: 2000, TC: 0, S: false, TTL: 32},
+			},
+		},
+	}
+
+	labels := extractMPLS(extensions)
+	if len(labels) != 2 {
+		t.Fatalf("extractMPLS返回%d个标签，期望2个", len(labels))
+	}
+
+	if labels[0].Label != 1000 || labels[0].Exp != 2 || !labels[0].S || labels[0].TTL != 64 {
+		t.Errorf("第一个标签字段不匹配: %+v", labels[0])
+	}
+	if labels[1].Label != 2000 || labels[1].Exp != 0 || labels[1].S || labels[1].TTL != 32 {
+		t.Errorf("第二个标签字段不匹配: %+v", labels[1])
+	}
+}
+
+func TestExtractMPLSWithoutLabelStack(t *testing.T) {
+	if labels := extractMPLS(nil); labels != nil {
+		t.Fatalf("extractMPLS(nil) = %v, 期望nil", labels)
+	}
+
+	extensions := []icmp.Extension{&icmp.RawExtension{Data: []byte{0x01}}}
+	if labels := extractMPLS(extensions); labels != nil {
+		t.Fatalf("extractMPLS应忽略非MPLS扩展, got %v", labels)
+	}
+}
+
+func TestEmbeddedProbeMatches(t *testing.T) {
+	dst := []byte{192, 0, 2, 1}
+	data := make([]byte, 24)
+	data[0] = 0x45 // IHL=5 (20字节)
+	copy(data[16:20], dst)
+	data[20], data[21] = 0x30, 0x39 // 源端口 12345
+	data[22], data[23] = 0x82, 0x36 // 目的端口 33334
+
+	if !embeddedProbeMatches(data, dst, 12345, 33334) {
+		t.Fatal("embeddedProbeMatches应匹配完全一致的探测包")
+	}
+	if embeddedProbeMatches(data, dst, 12345, 33335) {
+		t.Fatal("embeddedProbeMatches不应匹配目的端口不同的探测包")
+	}
+	if embeddedProbeMatches(data, dst, 54321, 33334) {
+		t.Fatal("embeddedProbeMatches不应匹配源端口不同的探测包")
+	}
+	if embeddedProbeMatches(data[:10], dst, 12345, 33334) {
+		t.Fatal("embeddedProbeMatches对截断数据应返回false")
+	}
+}